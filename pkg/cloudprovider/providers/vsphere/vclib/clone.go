@@ -0,0 +1,201 @@
+package vclib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Firmware types accepted by VMOptions.Firmware.
+const (
+	FirmwareBIOS = "bios"
+	FirmwareEFI  = "efi"
+)
+
+// LinuxCustomization carries the subset of CustomizationLinuxPrep that
+// Kubernetes node/PV provisioning needs to join a cloned Linux VM to the
+// network and domain.
+type LinuxCustomization struct {
+	HostName string
+	Domain   string
+}
+
+// WindowsCustomization carries the subset of CustomizationSysprep that
+// Kubernetes node/PV provisioning needs to sysprep a cloned Windows VM.
+type WindowsCustomization struct {
+	ComputerName        string
+	AdminPassword       string
+	ProductKey          string
+	Domain              string
+	DomainAdmin         string
+	DomainAdminPassword string
+}
+
+// GuestCustomization selects and configures the guest OS customization to
+// apply during Clone. Exactly one of Linux or Windows should be set.
+type GuestCustomization struct {
+	Linux   *LinuxCustomization
+	Windows *WindowsCustomization
+}
+
+// VMOptions describes how to create a new VM by cloning a template.
+type VMOptions struct {
+	Template           string
+	Folder             *object.Folder
+	ResourcePool       *object.ResourcePool
+	Datastore          *Datastore
+	StoragePod         *StoragePod
+	GuestCustomization *GuestCustomization
+	NetworkInterfaces  []NICSpec
+	Firmware           string
+	HardwareVersion    int
+	Annotation         string
+}
+
+// CloneSpec is a VMOptions plus the clone's destination name.
+type CloneSpec struct {
+	Name string
+	VMOptions
+}
+
+// Clone clones the receiver (which must be a template or a VM to be used as
+// one) into a new VM named spec.Name, applying the requested placement,
+// hardware and guest customization. This lets a provisioner create a
+// properly-configured dummy VM for volume creation, or a fully customized
+// node VM, instead of only being able to reuse an existing node's config.
+func (vm VirtualMachine) Clone(ctx context.Context, spec CloneSpec) (*VirtualMachine, error) {
+	if spec.Folder == nil {
+		return nil, fmt.Errorf("CloneSpec.Folder must be set")
+	}
+
+	configSpec := types.VirtualMachineConfigSpec{
+		Annotation: spec.Annotation,
+	}
+	if spec.HardwareVersion > 0 {
+		configSpec.Version = fmt.Sprintf("vmx-%02d", spec.HardwareVersion)
+	}
+	if spec.Firmware != "" {
+		configSpec.Firmware = spec.Firmware
+	}
+	for _, nic := range spec.NetworkInterfaces {
+		device, err := vm.newEthernetCard(ctx, nic)
+		if err != nil {
+			glog.Errorf("Failed to build NIC device for network %q, err: %+v", nic.NetworkName, err)
+			return nil, err
+		}
+		configSpec.DeviceChange = append(configSpec.DeviceChange, &types.VirtualDeviceConfigSpec{
+			Device:    device,
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+		})
+	}
+
+	cloneSpec := types.VirtualMachineCloneSpec{
+		Config:   &configSpec,
+		PowerOn:  false,
+		Template: false,
+	}
+	datastore := spec.Datastore
+	if spec.StoragePod != nil {
+		var resourcePool *object.ResourcePool
+		if spec.ResourcePool != nil {
+			resourcePool = spec.ResourcePool
+		}
+		recommendedDatastore, err := spec.StoragePod.GetDatastoreForDisk(ctx, StoragePlacementSpecCreateType, types.VirtualDiskSpec{}, resourcePool, nil)
+		if err != nil {
+			glog.Errorf("Failed to get SDRS recommended datastore from StoragePod: %s, err: %+v", spec.StoragePod.Name(), err)
+			return nil, err
+		}
+		datastore = recommendedDatastore
+	}
+	if datastore != nil {
+		dsRef := datastore.Reference()
+		cloneSpec.Location.Datastore = &dsRef
+	}
+	if spec.ResourcePool != nil {
+		rpRef := spec.ResourcePool.Reference()
+		cloneSpec.Location.Pool = &rpRef
+	}
+	if spec.GuestCustomization != nil {
+		customizationSpec, err := spec.GuestCustomization.toCustomizationSpec(spec.NetworkInterfaces)
+		if err != nil {
+			glog.Errorf("Failed to build guest customization spec for %q, err: %+v", spec.Name, err)
+			return nil, err
+		}
+		cloneSpec.Customization = &customizationSpec
+	}
+
+	task, err := vm.VirtualMachine.Clone(ctx, spec.Folder, spec.Name, cloneSpec)
+	if err != nil {
+		glog.Errorf("Failed to clone VM %q to %q, err: %+v", vm.Name(), spec.Name, err)
+		return nil, err
+	}
+	info, err := task.WaitForResult(ctx, nil)
+	if err != nil {
+		glog.Errorf("CloneVM_Task for %q failed, err: %+v", spec.Name, err)
+		return nil, err
+	}
+	newVMRef, ok := info.Result.(types.ManagedObjectReference)
+	if !ok {
+		return nil, fmt.Errorf("CloneVM_Task for %q returned unexpected result type %T", spec.Name, info.Result)
+	}
+	return &VirtualMachine{object.NewVirtualMachine(vm.Client(), newVMRef)}, nil
+}
+
+// toCustomizationSpec builds the govmomi CustomizationSpec for the selected
+// guest OS, assigning one adapter mapping per requested NIC: a static
+// CustomizationFixedIp/SubnetMask/Gateway when the NICSpec sets IPAddress, or
+// a DHCP adapter mapping otherwise.
+func (gc *GuestCustomization) toCustomizationSpec(nics []NICSpec) (types.CustomizationSpec, error) {
+	spec := types.CustomizationSpec{}
+	for _, nic := range nics {
+		ipSettings := types.CustomizationIPSettings{}
+		if nic.IPAddress != "" {
+			if nic.SubnetMask == "" {
+				return types.CustomizationSpec{}, fmt.Errorf("NICSpec for network %q sets IPAddress without SubnetMask", nic.NetworkName)
+			}
+			ipSettings.Ip = &types.CustomizationFixedIp{IpAddress: nic.IPAddress}
+			ipSettings.SubnetMask = nic.SubnetMask
+			ipSettings.Gateway = nic.Gateway
+		} else {
+			ipSettings.Ip = &types.CustomizationDhcpIpGenerator{}
+		}
+		spec.NicSettingMap = append(spec.NicSettingMap, types.CustomizationAdapterMapping{
+			Adapter: ipSettings,
+		})
+	}
+
+	switch {
+	case gc.Linux != nil:
+		spec.Identity = &types.CustomizationLinuxPrep{
+			HostName: &types.CustomizationFixedName{Name: gc.Linux.HostName},
+			Domain:   gc.Linux.Domain,
+		}
+	case gc.Windows != nil:
+		spec.Identity = &types.CustomizationSysprep{
+			UserData: types.CustomizationUserData{
+				ComputerName: &types.CustomizationFixedName{Name: gc.Windows.ComputerName},
+				ProductId:    gc.Windows.ProductKey,
+			},
+			GuiUnattended: types.CustomizationGuiUnattended{
+				Password: &types.CustomizationPassword{
+					PlainText: true,
+					Value:     gc.Windows.AdminPassword,
+				},
+			},
+			Identification: types.CustomizationIdentification{
+				JoinDomain:    gc.Windows.Domain,
+				DomainAdmin:   gc.Windows.DomainAdmin,
+				DomainAdminPassword: &types.CustomizationPassword{
+					PlainText: true,
+					Value:     gc.Windows.DomainAdminPassword,
+				},
+			},
+		}
+	default:
+		return spec, fmt.Errorf("GuestCustomization requires either Linux or Windows to be set")
+	}
+	return spec, nil
+}
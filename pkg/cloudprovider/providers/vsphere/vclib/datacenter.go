@@ -0,0 +1,32 @@
+package vclib
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// Datacenter extends the govmomi Datacenter object.
+type Datacenter struct {
+	*object.Datacenter
+}
+
+// GetAllDatastores returns every Datastore in the datacenter - the candidate
+// set ChooseDatastore filters and selects from when a datastore allow-list or
+// non-default selection strategy is configured without naming a StoragePod.
+func (dc *Datacenter) GetAllDatastores(ctx context.Context) ([]*Datastore, error) {
+	var dcMo mo.Datacenter
+	err := property.DefaultCollector(dc.Client()).RetrieveOne(ctx, dc.Reference(), []string{"datastore"}, &dcMo)
+	if err != nil {
+		glog.Errorf("Failed to list datastores in datacenter: %s, err: %+v", dc.Name(), err)
+		return nil, err
+	}
+	datastores := make([]*Datastore, 0, len(dcMo.Datastore))
+	for _, ref := range dcMo.Datastore {
+		datastores = append(datastores, &Datastore{object.NewDatastore(dc.Client(), ref), dc})
+	}
+	return datastores, nil
+}
@@ -1,7 +1,14 @@
 package vclib
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
 	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
 // Datastore extends the govmomi Datastore object
@@ -9,3 +16,95 @@ type Datastore struct {
 	*object.Datastore
 	datacenter *Datacenter
 }
+
+// Datacenter returns the Datacenter the Datastore belongs to.
+func (ds *Datastore) Datacenter() *Datacenter {
+	return ds.datacenter
+}
+
+// StoragePod extends the govmomi StoragePod (datastore cluster) object so
+// that Storage DRS can be asked to place a new or reconfigured disk within
+// the pod instead of the caller naming a single Datastore up front.
+type StoragePod struct {
+	*object.StoragePod
+	Datacenter *Datacenter
+}
+
+// SDRS placement spec types, see types.StoragePlacementSpec.Type.
+const (
+	StoragePlacementSpecCreateType      = "create"
+	StoragePlacementSpecReconfigureType = "reconfigure"
+)
+
+// GetDatastores returns the StoragePod's member Datastores - the candidate
+// set ChooseDatastore filters and selects from when a datastore allow-list or
+// non-default selection strategy is configured for the pod.
+func (pod *StoragePod) GetDatastores(ctx context.Context) ([]*Datastore, error) {
+	var podMo mo.StoragePod
+	err := property.DefaultCollector(pod.Client()).RetrieveOne(ctx, pod.Reference(), []string{"childEntity"}, &podMo)
+	if err != nil {
+		glog.Errorf("Failed to get member datastores for StoragePod: %s, err: %+v", pod.Name(), err)
+		return nil, err
+	}
+	datastores := make([]*Datastore, 0, len(podMo.ChildEntity))
+	for _, ref := range podMo.ChildEntity {
+		if ref.Type != "Datastore" {
+			continue
+		}
+		datastores = append(datastores, &Datastore{object.NewDatastore(pod.Client(), ref), pod.Datacenter})
+	}
+	return datastores, nil
+}
+
+// GetDatastoreForDisk asks Storage DRS to recommend a Datastore within the
+// pod and applies the top recommendation, returning the Datastore the disk
+// should actually be created or attached on. diskSpec is not included in the
+// placement request, so the recommendation does not take the disk's size or
+// format into account. storageSpecType
+// should be StoragePlacementSpecCreateType when placing a brand-new disk, or
+// StoragePlacementSpecReconfigureType when placing a disk being attached to vm.
+// vm may be nil when no VM exists yet (e.g. a new PV's backing disk).
+func (pod *StoragePod) GetDatastoreForDisk(ctx context.Context, storageSpecType string, diskSpec types.VirtualDiskSpec, resourcePool *object.ResourcePool, vm *object.VirtualMachine) (*Datastore, error) {
+	podSelectionSpec := types.StorageDrsPodSelectionSpec{
+		StoragePod: types.NewReference(pod.Reference()),
+	}
+	storagePlacementSpec := types.StoragePlacementSpec{
+		Type:             storageSpecType,
+		PodSelectionSpec: podSelectionSpec,
+		DisklocatorSpec:  []types.VirtualMachineRelocateSpecDiskLocator{},
+	}
+	if resourcePool != nil {
+		rpRef := resourcePool.Reference()
+		storagePlacementSpec.ResourcePool = &rpRef
+	}
+	if vm != nil {
+		vmRef := vm.Reference()
+		storagePlacementSpec.Vm = &vmRef
+	}
+
+	srm := object.NewStorageResourceManager(pod.Client())
+	result, err := srm.RecommendDatastores(ctx, storagePlacementSpec)
+	if err != nil {
+		glog.Errorf("Failed to get datastore recommendations from StoragePod: %s, err: %+v", pod.Name(), err)
+		return nil, err
+	}
+	if len(result.Recommendations) == 0 {
+		return nil, fmt.Errorf("No Storage DRS recommendations found for StoragePod: %s", pod.Name())
+	}
+
+	// Apply the top recommendation so vCenter reserves the space it picked.
+	recommendation := result.Recommendations[0]
+	_, err = srm.ApplyStorageDrsRecommendation(ctx, []string{recommendation.Key})
+	if err != nil {
+		glog.Errorf("Failed to apply Storage DRS recommendation %q for StoragePod: %s, err: %+v", recommendation.Key, pod.Name(), err)
+		return nil, err
+	}
+	if len(recommendation.Action) == 0 {
+		return nil, fmt.Errorf("Storage DRS recommendation %q for StoragePod: %s has no placement action", recommendation.Key, pod.Name())
+	}
+	action, ok := recommendation.Action[0].(*types.StoragePlacementAction)
+	if !ok {
+		return nil, fmt.Errorf("Unexpected Storage DRS recommendation action type: %T", recommendation.Action[0])
+	}
+	return &Datastore{object.NewDatastore(pod.Client(), action.Destination), pod.Datacenter}, nil
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package vclib
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+)
+
+// Datastore selection strategies for the "datastoreSelectionStrategy" StorageClass parameter.
+const (
+	DatastoreSelectionRandom        = "random"
+	DatastoreSelectionMostFreeSpace = "mostFreeSpace"
+	DatastoreSelectionRoundRobin    = "roundRobin"
+)
+
+// PV annotations used to surface why a particular datastore was chosen (or
+// why provisioning failed) for a VSAN/SPBM-provisioned volume.
+const (
+	AnnotationChosenDatastore    = "volume.vsphere.kubernetes.io/chosen-datastore"
+	AnnotationRejectedDatastores = "volume.vsphere.kubernetes.io/rejected-datastores"
+)
+
+// DatastorePlacementResult records the outcome of ChooseDatastore: which
+// datastore was picked, why every compatible candidate was accepted, and why
+// every rejected candidate was rejected.
+type DatastorePlacementResult struct {
+	Chosen               string
+	CompatibilityReasons map[string]string
+	Rejected             map[string]string
+}
+
+// PVAnnotations renders the placement result as the PV annotations operators
+// can use to debug provisioning failures.
+func (r *DatastorePlacementResult) PVAnnotations() map[string]string {
+	annotations := map[string]string{}
+	if r.Chosen != "" {
+		annotations[AnnotationChosenDatastore] = r.Chosen
+	}
+	if len(r.Rejected) > 0 {
+		reasons := make([]string, 0, len(r.Rejected))
+		for name, reason := range r.Rejected {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", name, reason))
+		}
+		sort.Strings(reasons)
+		annotations[AnnotationRejectedDatastores] = strings.Join(reasons, "; ")
+	}
+	return annotations
+}
+
+// roundRobinCounter tracks the last index handed out by the roundRobin
+// selection strategy across calls to ChooseDatastore. PVs can be provisioned
+// concurrently, so access is guarded by roundRobinMutex.
+var (
+	roundRobinMutex   sync.Mutex
+	roundRobinCounter int
+)
+
+// ChooseDatastore filters compatibleDatastores - the candidates a VSAN
+// capability set or named SPBM profile is actually satisfiable on - down to
+// allowList (the "datastores"/"datastoreCluster" StorageClass parameter)
+// when non-empty, and picks one of the survivors using strategy. It always
+// returns a DatastorePlacementResult recording its reasoning, even on
+// failure, so the caller can surface it on the PV.
+func ChooseDatastore(ctx context.Context, compatibleDatastores []*Datastore, allowList []string, strategy string) (*DatastorePlacementResult, error) {
+	result := &DatastorePlacementResult{
+		CompatibilityReasons: make(map[string]string),
+		Rejected:             make(map[string]string),
+	}
+
+	candidates := compatibleDatastores
+	if len(allowList) > 0 {
+		allowed := make(map[string]bool, len(allowList))
+		for _, name := range allowList {
+			allowed[name] = true
+		}
+		candidates = nil
+		for _, ds := range compatibleDatastores {
+			if allowed[ds.Name()] {
+				candidates = append(candidates, ds)
+				result.CompatibilityReasons[ds.Name()] = "compatible and in the datastore allow-list"
+			} else {
+				result.Rejected[ds.Name()] = "compatible but not in the datastore allow-list"
+			}
+		}
+	} else {
+		for _, ds := range compatibleDatastores {
+			result.CompatibilityReasons[ds.Name()] = "compatible"
+		}
+	}
+
+	if len(candidates) == 0 {
+		if len(allowList) > 0 {
+			return result, fmt.Errorf("No compatible datastore found within the configured allow-list")
+		}
+		return result, fmt.Errorf("No compatible datastore found")
+	}
+
+	var chosen *Datastore
+	switch strategy {
+	case DatastoreSelectionMostFreeSpace:
+		var bestFreeSpace int64 = -1
+		for _, ds := range candidates {
+			freeSpace, err := datastoreFreeSpace(ctx, ds)
+			if err != nil {
+				glog.Errorf("Failed to get free space for datastore %q, skipping it for mostFreeSpace selection, err: %+v", ds.Name(), err)
+				continue
+			}
+			if chosen == nil || freeSpace > bestFreeSpace {
+				chosen = ds
+				bestFreeSpace = freeSpace
+			}
+		}
+		if chosen == nil {
+			return result, fmt.Errorf("Failed to determine free space for any candidate datastore")
+		}
+	case DatastoreSelectionRoundRobin:
+		roundRobinMutex.Lock()
+		chosen = candidates[roundRobinCounter%len(candidates)]
+		roundRobinCounter++
+		roundRobinMutex.Unlock()
+	default:
+		chosen = candidates[rand.Intn(len(candidates))]
+	}
+	result.Chosen = chosen.Name()
+	return result, nil
+}
+
+// datastoreFreeSpace returns the datastore's reported free space in bytes.
+func datastoreFreeSpace(ctx context.Context, ds *Datastore) (int64, error) {
+	var dsMo mo.Datastore
+	err := property.DefaultCollector(ds.Client()).RetrieveOne(ctx, ds.Reference(), []string{"summary.freeSpace"}, &dsMo)
+	if err != nil {
+		return 0, err
+	}
+	return dsMo.Summary.FreeSpace, nil
+}
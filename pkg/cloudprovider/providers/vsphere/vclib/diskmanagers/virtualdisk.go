@@ -3,6 +3,9 @@ package diskmanagers
 import (
 	"fmt"
 	"github.com/golang/glog"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/types"
 	"golang.org/x/net/context"
 	"k8s.io/kubernetes/pkg/cloudprovider/providers/vsphere/vclib"
 )
@@ -42,7 +45,19 @@ func getDiskManager(disk *VirtualDisk, diskOperation string) VirtualDiskProvider
 	return diskProvider
 }
 
-// Create gets appropriate disk manager and calls respective create method
+// Create gets appropriate disk manager and calls respective create method.
+// If the VolumeOptions name a StoragePolicyName instead of a StoragePolicyID,
+// the name is resolved to its profile ID via PBM first, since every
+// downstream consumer of VolumeOptions (createDiskSpec, AttachDisk) only
+// understands the ID. If the VolumeOptions name a StoragePod (datastore
+// cluster) and no datastore allow-list or selection strategy is configured,
+// Storage DRS is asked to recommend a member Datastore and the disk is
+// created there instead of the passed-in datastore, enabling SDRS to balance
+// PVs across a cluster. If a datastore allow-list or selection strategy is
+// configured, ChooseDatastore picks the disk's datastore instead - from the
+// named StoragePod's members if one is set, or from every datastore in the
+// passed-in datastore's datacenter otherwise - so the allow-list and strategy
+// are honored whether or not a datastore cluster is named.
 func (virtualDisk *VirtualDisk) Create(ctx context.Context, datastore *vclib.Datastore) error {
 	if virtualDisk.VolumeOptions.DiskFormat == "" {
 		virtualDisk.VolumeOptions.DiskFormat = vclib.ThinDiskType
@@ -54,10 +69,129 @@ func (virtualDisk *VirtualDisk) Create(ctx context.Context, datastore *vclib.Dat
 	if virtualDisk.VolumeOptions.StoragePolicyID != "" && virtualDisk.VolumeOptions.StoragePolicyName != "" {
 		return fmt.Errorf("Storage Policy ID and Storage Policy Name both set, Please set only one parameter")
 	}
+	if virtualDisk.VolumeOptions.StoragePolicyName != "" {
+		storagePolicyID, err := vclib.GetStoragePolicyID(ctx, datastore.Client(), virtualDisk.VolumeOptions.StoragePolicyName)
+		if err != nil {
+			glog.Errorf("Failed to resolve storage policy name %q to a profile ID, err: %+v", virtualDisk.VolumeOptions.StoragePolicyName, err)
+			return err
+		}
+		virtualDisk.VolumeOptions.StoragePolicyID = storagePolicyID
+	}
+	placementWanted := len(virtualDisk.VolumeOptions.Datastores) > 0 || virtualDisk.VolumeOptions.DatastoreSelectionStrategy != ""
+	switch {
+	case virtualDisk.VolumeOptions.StoragePod != nil && placementWanted:
+		chosenDatastore, placement, err := virtualDisk.choosePodDatastore(ctx)
+		if err != nil {
+			glog.Errorf("Failed to choose a datastore within StoragePod: %s, err: %+v. Placement reasoning: %+v", virtualDisk.VolumeOptions.StoragePod.Name(), err, placement)
+			return err
+		}
+		datastore = chosenDatastore
+	case virtualDisk.VolumeOptions.StoragePod != nil:
+		recommendedDatastore, err := virtualDisk.VolumeOptions.StoragePod.GetDatastoreForDisk(ctx, vclib.StoragePlacementSpecCreateType, types.VirtualDiskSpec{}, nil, nil)
+		if err != nil {
+			glog.Errorf("Failed to get SDRS recommended datastore from StoragePod: %s, err: %+v", virtualDisk.VolumeOptions.StoragePod.Name(), err)
+			return err
+		}
+		datastore = recommendedDatastore
+	case placementWanted:
+		chosenDatastore, placement, err := virtualDisk.chooseDatacenterDatastore(ctx, datastore.Datacenter())
+		if err != nil {
+			glog.Errorf("Failed to choose a datastore in datacenter: %s, err: %+v. Placement reasoning: %+v", datastore.Datacenter().Name(), err, placement)
+			return err
+		}
+		datastore = chosenDatastore
+	}
 	return getDiskManager(virtualDisk, VirtualDiskCreateOperation).Create(ctx, datastore)
 }
 
+// choosePodDatastore lists the StoragePod's member Datastores and runs
+// chooseFromCandidates over them. It is only used in place of plain SDRS
+// placement when the caller actually configured an allow-list or a
+// non-default selection strategy.
+func (virtualDisk *VirtualDisk) choosePodDatastore(ctx context.Context) (*vclib.Datastore, *vclib.DatastorePlacementResult, error) {
+	pod := virtualDisk.VolumeOptions.StoragePod
+	members, err := pod.GetDatastores(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return virtualDisk.chooseFromCandidates(ctx, pod.Client(), members)
+}
+
+// chooseDatacenterDatastore lists every Datastore in dc and runs
+// chooseFromCandidates over them. It is used in place of choosePodDatastore
+// when the caller configured an allow-list or selection strategy without
+// naming a StoragePod.
+func (virtualDisk *VirtualDisk) chooseDatacenterDatastore(ctx context.Context, dc *vclib.Datacenter) (*vclib.Datastore, *vclib.DatastorePlacementResult, error) {
+	members, err := dc.GetAllDatastores(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return virtualDisk.chooseFromCandidates(ctx, dc.Client(), members)
+}
+
+// chooseFromCandidates narrows candidates down to the ones PBM reports as
+// compatible with VolumeOptions.StoragePolicyID when one is set, and runs
+// vclib.ChooseDatastore over the survivors with the VolumeOptions' datastore
+// allow-list and selection strategy, recording the placement reasoning on
+// VolumeOptions.PlacementAnnotations so the provisioner can copy it onto the
+// PV.
+func (virtualDisk *VirtualDisk) chooseFromCandidates(ctx context.Context, client *vim25.Client, candidates []*vclib.Datastore) (*vclib.Datastore, *vclib.DatastorePlacementResult, error) {
+	var err error
+	if virtualDisk.VolumeOptions.StoragePolicyID != "" {
+		candidates, err = vclib.FilterCompatibleDatastores(ctx, client, virtualDisk.VolumeOptions.StoragePolicyID, candidates)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	placement, err := vclib.ChooseDatastore(ctx, candidates, virtualDisk.VolumeOptions.Datastores, virtualDisk.VolumeOptions.DatastoreSelectionStrategy)
+	virtualDisk.VolumeOptions.PlacementAnnotations = placement.PVAnnotations()
+	if err != nil {
+		return nil, placement, err
+	}
+	for _, ds := range candidates {
+		if ds.Name() == placement.Chosen {
+			return ds, placement, nil
+		}
+	}
+	return nil, placement, fmt.Errorf("Datastore %q chosen by ChooseDatastore is not among the candidates considered", placement.Chosen)
+}
+
+// CreateFromSnapshot provisions the disk as a linked clone of snapshot.
+// vSphere has no way to parent a disk to a snapshot's vmdk on its own, only
+// as part of cloning the VM that owns it (see VirtualMachine.CreateLinkedClone),
+// so this clones baseVM - the VM snapshot belongs to - into a new, lightweight
+// VM named spec.Name and sets DiskPath to that VM's root disk, giving
+// near-instant provisioning from a golden snapshot at the cost of the new
+// disk staying dependent on the parent's disk chain.
+func (virtualDisk *VirtualDisk) CreateFromSnapshot(ctx context.Context, baseVM vclib.VirtualMachine, snapshot *vclib.Snapshot, spec vclib.CloneSpec) error {
+	linkedCloneVM, err := baseVM.CreateLinkedClone(ctx, snapshot, spec)
+	if err != nil {
+		glog.Errorf("Failed to create linked clone of %q from snapshot %q, err: %+v", baseVM.Name(), snapshot.Name, err)
+		return err
+	}
+	diskPath, err := linkedCloneVM.GetRootDiskPath(ctx)
+	if err != nil {
+		glog.Errorf("Failed to get root disk path of linked clone %q, err: %+v", spec.Name, err)
+		return err
+	}
+	virtualDisk.DiskPath = diskPath
+	return nil
+}
+
 // Delete gets appropriate disk manager and calls respective delete method
 func (virtualDisk *VirtualDisk) Delete(ctx context.Context, datastore *vclib.Datastore) error {
 	return getDiskManager(virtualDisk, VirtualDiskDeleteOperation).Delete(ctx, datastore)
 }
+
+// Expand grows a detached virtual disk (not attached to any VM) on datastore
+// to newSizeKB using VirtualDiskManager.ExtendVirtualDisk_Task. This covers
+// online resize of a PV whose disk has not yet been attached to a node.
+func (virtualDisk *VirtualDisk) Expand(ctx context.Context, datastore *vclib.Datastore, newSizeKB int64) error {
+	vdm := object.NewVirtualDiskManager(datastore.Client())
+	task, err := vdm.ExtendVirtualDisk(ctx, virtualDisk.DiskPath, datastore.Datacenter().Datacenter, newSizeKB, nil)
+	if err != nil {
+		glog.Errorf("Failed to extend virtual disk %q to %d KB, err: %v", virtualDisk.DiskPath, newSizeKB, err)
+		return err
+	}
+	return task.Wait(ctx)
+}
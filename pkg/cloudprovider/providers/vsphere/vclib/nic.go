@@ -0,0 +1,178 @@
+package vclib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Adapter types accepted by NICSpec.AdapterType.
+const (
+	NICAdapterTypeVmxnet3 = "vmxnet3"
+	NICAdapterTypeE1000   = "e1000"
+	NICAdapterTypeE1000e  = "e1000e"
+	NICAdapterTypePCNet32 = "pcnet32"
+	NICAdapterTypeSRIOV   = "sriov"
+)
+
+// NICSpec describes a single virtual network adapter to attach to a VM,
+// either at clone time via VMOptions.NetworkInterfaces or afterwards via
+// VirtualMachine.AddNIC. When DVSPortgroup is true, NetworkName is resolved
+// as a distributed portgroup rather than a standard vSwitch network.
+// IPAddress, SubnetMask and Gateway configure the guest customization applied
+// at clone time (see Clone/toCustomizationSpec); when IPAddress is empty the
+// adapter is customized for DHCP instead. They have no effect on AddNIC,
+// which only attaches the device and does not run guest customization.
+type NICSpec struct {
+	NetworkName  string
+	AdapterType  string
+	MACAddress   string
+	DVSPortgroup bool
+	IPAddress    string
+	SubnetMask   string
+	Gateway      []string
+}
+
+// NICInfo describes a network adapter already attached to a VM, as returned by ListNICs.
+type NICInfo struct {
+	DeviceName  string
+	NetworkName string
+	AdapterType string
+	MACAddress  string
+}
+
+// AddNIC resolves nic.NetworkName and attaches a new virtual network adapter
+// of nic.AdapterType to the VM, returning the device name of the attached
+// NIC. This is a prerequisite for Clone-based provisioning and is also
+// useful standalone for node-config reconciliation.
+func (vm VirtualMachine) AddNIC(ctx context.Context, nic NICSpec) (string, error) {
+	device, err := vm.newEthernetCard(ctx, nic)
+	if err != nil {
+		glog.Errorf("Failed to build NIC device for network %q, err: %+v", nic.NetworkName, err)
+		return "", err
+	}
+	if err := vm.AddDevice(ctx, device); err != nil {
+		glog.Errorf("Failed to add NIC device for network %q, err: %+v", nic.NetworkName, err)
+		return "", err
+	}
+	vmDevices, err := vm.Device(ctx)
+	if err != nil {
+		glog.Errorf("Failed to get the devices for vm: %+v. err: %+v", vm, err)
+		return "", err
+	}
+	devices := vmDevices.SelectByType(device)
+	if len(devices) < 1 {
+		return "", ErrNoDevicesFound
+	}
+	return vmDevices.Name(devices[len(devices)-1]), nil
+}
+
+// RemoveNIC removes the virtual network adapter identified by deviceName from the VM.
+func (vm VirtualMachine) RemoveNIC(ctx context.Context, deviceName string) error {
+	vmDevices, err := vm.Device(ctx)
+	if err != nil {
+		glog.Errorf("Error occurred while getting VM devices, err: %v", err)
+		return err
+	}
+	device := vmDevices.Find(deviceName)
+	if device == nil {
+		return fmt.Errorf("device '%s' not found", deviceName)
+	}
+	if err := vm.RemoveDevice(ctx, true, device); err != nil {
+		glog.Errorf("Error occurred while removing NIC device, err: %v", err)
+		return err
+	}
+	return nil
+}
+
+// ListNICs returns the network adapters currently attached to the VM.
+func (vm VirtualMachine) ListNICs(ctx context.Context) ([]NICInfo, error) {
+	vmDevices, err := vm.Device(ctx)
+	if err != nil {
+		glog.Errorf("Error occurred while getting VM devices, err: %v", err)
+		return nil, err
+	}
+	var nics []NICInfo
+	for _, device := range vmDevices {
+		card, ok := device.(types.BaseVirtualEthernetCard)
+		if !ok {
+			continue
+		}
+		ethernetCard := card.GetVirtualEthernetCard()
+		info := NICInfo{
+			DeviceName:  vmDevices.Name(device),
+			AdapterType: ethernetCardAdapterType(device),
+			MACAddress:  ethernetCard.MacAddress,
+		}
+		switch backing := ethernetCard.Backing.(type) {
+		case *types.VirtualEthernetCardNetworkBackingInfo:
+			info.NetworkName = backing.DeviceName
+		case *types.VirtualEthernetCardDistributedVirtualPortBackingInfo:
+			info.NetworkName = backing.Port.PortgroupKey
+		}
+		nics = append(nics, info)
+	}
+	return nics, nil
+}
+
+func ethernetCardAdapterType(device types.BaseVirtualDevice) string {
+	switch device.(type) {
+	case *types.VirtualVmxnet3:
+		return NICAdapterTypeVmxnet3
+	case *types.VirtualE1000:
+		return NICAdapterTypeE1000
+	case *types.VirtualE1000e:
+		return NICAdapterTypeE1000e
+	case *types.VirtualPCNet32:
+		return NICAdapterTypePCNet32
+	case *types.VirtualSriovEthernetCard:
+		return NICAdapterTypeSRIOV
+	default:
+		return ""
+	}
+}
+
+// newEthernetCard resolves nic.NetworkName - as a DVS portgroup when
+// nic.DVSPortgroup is set, otherwise as a standard vSwitch network - and
+// builds the virtual ethernet card device described by nic, ready to be
+// added to the VM directly or included in a VirtualDeviceConfigSpec.
+func (vm VirtualMachine) newEthernetCard(ctx context.Context, nic NICSpec) (types.BaseVirtualDevice, error) {
+	finder := find.NewFinder(vm.Client(), false)
+	networks, err := finder.NetworkList(ctx, nic.NetworkName)
+	if err != nil {
+		glog.Errorf("Failed to find network %q, err: %+v", nic.NetworkName, err)
+		return nil, err
+	}
+	var network object.NetworkReference
+	for _, n := range networks {
+		_, isDVSPortgroup := n.(*object.DistributedVirtualPortgroup)
+		_, isStandardNetwork := n.(*object.Network)
+		if (nic.DVSPortgroup && isDVSPortgroup) || (!nic.DVSPortgroup && isStandardNetwork) {
+			network = n
+			break
+		}
+	}
+	if network == nil {
+		return nil, fmt.Errorf("Network %q not found", nic.NetworkName)
+	}
+	backing, err := network.EthernetCardBackingInfo(ctx)
+	if err != nil {
+		glog.Errorf("Failed to get EthernetCardBackingInfo for network %q, err: %+v", nic.NetworkName, err)
+		return nil, err
+	}
+	device, err := object.EthernetCardTypes().CreateEthernetCard(nic.AdapterType, backing)
+	if err != nil {
+		glog.Errorf("Failed to create ethernet card of type %q, err: %+v", nic.AdapterType, err)
+		return nil, err
+	}
+	if nic.MACAddress != "" {
+		card := device.(types.BaseVirtualEthernetCard).GetVirtualEthernetCard()
+		card.AddressType = string(types.VirtualEthernetCardMacTypeManual)
+		card.MacAddress = nic.MACAddress
+	}
+	return device, nil
+}
@@ -0,0 +1,64 @@
+package vclib
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	"github.com/vmware/govmomi/pbm"
+	pbmtypes "github.com/vmware/govmomi/pbm/types"
+	"github.com/vmware/govmomi/vim25"
+)
+
+// GetStoragePolicyID resolves a named SPBM storage policy - the
+// "storagePolicyName" StorageClass parameter - to the profile ID vCenter
+// actually tracks disk placement and compliance by. AttachDisk and
+// createDiskSpec only understand VolumeOptions.StoragePolicyID, so callers
+// that only have a policy name must resolve it with this before creating or
+// attaching a disk.
+func GetStoragePolicyID(ctx context.Context, client *vim25.Client, policyName string) (string, error) {
+	pbmClient, err := pbm.NewClient(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	return pbmClient.ProfileIDByName(ctx, policyName)
+}
+
+// FilterCompatibleDatastores narrows datastores down to the ones PBM reports
+// as actually capable of satisfying the SPBM profile identified by
+// storagePolicyID, via PbmCheckRequirements. This is what makes
+// ChooseDatastore's candidate list genuinely "compatible" rather than just
+// "a member of the StoragePod" when a storage policy is in play.
+func FilterCompatibleDatastores(ctx context.Context, client *vim25.Client, storagePolicyID string, datastores []*Datastore) ([]*Datastore, error) {
+	pbmClient, err := pbm.NewClient(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	hubs := make([]pbmtypes.PbmPlacementHub, 0, len(datastores))
+	for _, ds := range datastores {
+		hubs = append(hubs, pbmtypes.PbmPlacementHub{
+			HubType: "Datastore",
+			HubId:   ds.Reference().Value,
+		})
+	}
+	req := []pbmtypes.BasePbmPlacementRequirement{
+		&pbmtypes.PbmPlacementCapabilityProfileRequirement{
+			ProfileId: pbmtypes.PbmProfileId{UniqueId: storagePolicyID},
+		},
+	}
+	result, err := pbmClient.CheckRequirements(ctx, hubs, nil, req)
+	if err != nil {
+		glog.Errorf("PbmCheckRequirements failed for storage policy %q, err: %+v", storagePolicyID, err)
+		return nil, err
+	}
+	compatibleHubs := make(map[string]bool, len(result.CompatibleDatastores()))
+	for _, hub := range result.CompatibleDatastores() {
+		compatibleHubs[hub.HubId] = true
+	}
+	compatible := make([]*Datastore, 0, len(datastores))
+	for _, ds := range datastores {
+		if compatibleHubs[ds.Reference().Value] {
+			compatible = append(compatible, ds)
+		}
+	}
+	return compatible, nil
+}
@@ -0,0 +1,130 @@
+package vclib
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/golang/glog"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/property"
+	"github.com/vmware/govmomi/vim25/mo"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Snapshot identifies a VM snapshot by the name it was created with and its
+// managed object reference.
+type Snapshot struct {
+	Name      string
+	Reference types.ManagedObjectReference
+}
+
+// CreateSnapshot creates a new snapshot of the VM. Set memory to also
+// snapshot the VM's memory state, and quiesce to quiesce the guest
+// filesystem before snapshotting (requires VMware Tools).
+func (vm VirtualMachine) CreateSnapshot(ctx context.Context, name, description string, memory, quiesce bool) (*Snapshot, error) {
+	task, err := vm.VirtualMachine.CreateSnapshot(ctx, name, description, memory, quiesce)
+	if err != nil {
+		glog.Errorf("Failed to create snapshot %q for vm: %+v, err: %+v", name, vm, err)
+		return nil, err
+	}
+	info, err := task.WaitForResult(ctx, nil)
+	if err != nil {
+		glog.Errorf("CreateSnapshot_Task for %q failed, err: %+v", name, err)
+		return nil, err
+	}
+	ref, ok := info.Result.(types.ManagedObjectReference)
+	if !ok {
+		return nil, fmt.Errorf("CreateSnapshot_Task for %q returned unexpected result type %T", name, info.Result)
+	}
+	return &Snapshot{Name: name, Reference: ref}, nil
+}
+
+// RemoveSnapshot removes the given snapshot, optionally consolidating the disk chain.
+func (vm VirtualMachine) RemoveSnapshot(ctx context.Context, snapshot *Snapshot, consolidate bool) error {
+	task, err := vm.VirtualMachine.RemoveSnapshot(ctx, snapshot.Reference.Value, false, &consolidate)
+	if err != nil {
+		glog.Errorf("Failed to remove snapshot %q, err: %+v", snapshot.Name, err)
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// RevertToSnapshot reverts the VM to the given snapshot.
+func (vm VirtualMachine) RevertToSnapshot(ctx context.Context, snapshot *Snapshot) error {
+	task, err := vm.VirtualMachine.RevertToSnapshot(ctx, snapshot.Reference.Value, false)
+	if err != nil {
+		glog.Errorf("Failed to revert to snapshot %q, err: %+v", snapshot.Name, err)
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// CreateLinkedClone clones the receiver into a new VM named spec.Name whose
+// disks are child (delta) disks backed by snapshot's vmdks, by passing
+// snapshot's reference and the createNewChildDiskBacking disk move option to
+// VirtualMachineCloneSpec. This is the only vSphere mechanism for a true
+// linked clone - a disk cannot be parented to a snapshot's vmdk on its own,
+// only as part of cloning the VM that owns it - so PV provisioning "from
+// snapshot" creates this lightweight linked-clone VM and reuses its disk as
+// the new PV's backing, giving near-instant provisioning from a golden
+// snapshot at the cost of the new disk staying dependent on the parent's
+// disk chain.
+func (vm VirtualMachine) CreateLinkedClone(ctx context.Context, snapshot *Snapshot, spec CloneSpec) (*VirtualMachine, error) {
+	if spec.Folder == nil {
+		return nil, fmt.Errorf("CloneSpec.Folder must be set")
+	}
+	cloneSpec := types.VirtualMachineCloneSpec{
+		PowerOn:  false,
+		Template: false,
+		Snapshot: &snapshot.Reference,
+	}
+	cloneSpec.Location.DiskMoveType = string(types.VirtualMachineRelocateDiskMoveOptionsCreateNewChildDiskBacking)
+	if spec.Datastore != nil {
+		dsRef := spec.Datastore.Reference()
+		cloneSpec.Location.Datastore = &dsRef
+	}
+	if spec.ResourcePool != nil {
+		rpRef := spec.ResourcePool.Reference()
+		cloneSpec.Location.Pool = &rpRef
+	}
+
+	task, err := vm.VirtualMachine.Clone(ctx, spec.Folder, spec.Name, cloneSpec)
+	if err != nil {
+		glog.Errorf("Failed to create linked clone of %q from snapshot %q, err: %+v", vm.Name(), snapshot.Name, err)
+		return nil, err
+	}
+	info, err := task.WaitForResult(ctx, nil)
+	if err != nil {
+		glog.Errorf("CloneVM_Task for linked clone %q failed, err: %+v", spec.Name, err)
+		return nil, err
+	}
+	newVMRef, ok := info.Result.(types.ManagedObjectReference)
+	if !ok {
+		return nil, fmt.Errorf("CloneVM_Task for %q returned unexpected result type %T", spec.Name, info.Result)
+	}
+	return &VirtualMachine{object.NewVirtualMachine(vm.Client(), newVMRef)}, nil
+}
+
+// ListSnapshots returns every snapshot currently taken of the VM, flattened
+// out of the snapshot tree.
+func (vm VirtualMachine) ListSnapshots(ctx context.Context) ([]Snapshot, error) {
+	var vmMo mo.VirtualMachine
+	err := property.DefaultCollector(vm.Client()).RetrieveOne(ctx, vm.Reference(), []string{"snapshot"}, &vmMo)
+	if err != nil {
+		glog.Errorf("Failed to get snapshot info for vm: %+v. err: %+v", vm, err)
+		return nil, err
+	}
+	if vmMo.Snapshot == nil {
+		return nil, nil
+	}
+	var snapshots []Snapshot
+	var walk func(tree []types.VirtualMachineSnapshotTree)
+	walk = func(tree []types.VirtualMachineSnapshotTree) {
+		for _, node := range tree {
+			snapshots = append(snapshots, Snapshot{Name: node.Name, Reference: node.Snapshot})
+			walk(node.ChildSnapshotList)
+		}
+	}
+	walk(vmMo.Snapshot.RootSnapshotList)
+	return snapshots, nil
+}
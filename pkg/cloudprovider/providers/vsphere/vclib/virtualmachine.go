@@ -16,6 +16,29 @@ type VirtualMachine struct {
 	*object.VirtualMachine
 }
 
+// SCSI bus sharing modes accepted in VolumeOptions.SCSISharing and the
+// "scsiSharing" StorageClass parameter. Sharing the SCSI bus lets a disk be
+// attached to more than one VM at a time, which is required for clustered
+// workloads such as Oracle RAC or MSCS.
+const (
+	SCSISharingNoSharing       = "noSharing"
+	SCSISharingVirtualSharing  = "virtualSharing"
+	SCSISharingPhysicalSharing = "physicalSharing"
+)
+
+// getSCSISharingType maps a SCSISharing VolumeOptions value to the
+// corresponding govmomi VirtualSCSISharing mode, defaulting to no sharing.
+func getSCSISharingType(sharing string) types.VirtualSCSISharing {
+	switch sharing {
+	case SCSISharingVirtualSharing:
+		return types.VirtualSCSISharingVirtualSharing
+	case SCSISharingPhysicalSharing:
+		return types.VirtualSCSISharingPhysicalSharing
+	default:
+		return types.VirtualSCSISharingNoSharing
+	}
+}
+
 // IsDiskAttached checks if disk is attached to the VM.
 func (vm VirtualMachine) IsDiskAttached(ctx context.Context, diskPath string) (bool, error) {
 	// Get object key of controller to which disk is attached
@@ -30,6 +53,53 @@ func (vm VirtualMachine) IsDiskAttached(ctx context.Context, diskPath string) (b
 	return true, nil
 }
 
+// IsDiskSharedWithVM returns true if the disk at diskPath is attached to the VM
+// as a multi-writer (shared) disk, e.g. for clustered workloads such as Oracle
+// RAC or MSCS that require simultaneous access from multiple VMs.
+func (vm VirtualMachine) IsDiskSharedWithVM(ctx context.Context, diskPath string) (bool, error) {
+	vmDevices, err := vm.Device(ctx)
+	if err != nil {
+		glog.Errorf("Failed to get the devices for vm: %+v. err: %+v", vm, err)
+		return false, err
+	}
+	device, err := vm.getVirtualDeviceByPath(ctx, vmDevices, diskPath)
+	if err != nil {
+		glog.Errorf("Failed to get virtualDevice for path: %q. err: %+v", diskPath, err)
+		return false, err
+	}
+	if device == nil {
+		return false, nil
+	}
+	backing, ok := device.GetVirtualDevice().Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+	if !ok {
+		return false, nil
+	}
+	return backing.Sharing == types.VirtualDiskSharingSharingMultiWriter, nil
+}
+
+// GetRootDiskPath returns the datastore path of the first VirtualDisk device
+// attached to the VM - the disk a freshly cloned VM (for example, one just
+// created by CreateLinkedClone) was created with, before any of this
+// package's own AttachDisk/AttachDisks calls add more.
+func (vm VirtualMachine) GetRootDiskPath(ctx context.Context) (string, error) {
+	vmDevices, err := vm.Device(ctx)
+	if err != nil {
+		glog.Errorf("Failed to get the devices for vm: %+v. err: %+v", vm, err)
+		return "", err
+	}
+	for _, device := range vmDevices {
+		if vmDevices.TypeName(device) != "VirtualDisk" {
+			continue
+		}
+		backing, ok := device.GetVirtualDevice().Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+		if !ok {
+			continue
+		}
+		return backing.FileName, nil
+	}
+	return "", ErrNoDevicesFound
+}
+
 // GetVirtualDiskUUIDByPath gets the virtual disk UUID by datastore (namespace) path
 //
 // volPath can be namespace path (e.g. "[vsanDatastore] volumes/test.vmdk") or
@@ -80,6 +150,18 @@ func (vm VirtualMachine) getVirtualDeviceByPath(ctx context.Context, vmDevices o
 			}
 		}
 	}
+	// A multi-writer (shared) disk is backed by the same vmdk file on every VM it
+	// is attached to, but the UUID reported for it can vary by VM depending on
+	// disk-lock ownership, so fall back to matching on the backing file name.
+	for _, device := range vmDevices {
+		if vmDevices.TypeName(device) == "VirtualDisk" {
+			if backing, ok := device.GetVirtualDevice().Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+				if backing.FileName == diskPath {
+					return device, nil
+				}
+			}
+		}
+	}
 	return nil, nil
 }
 
@@ -128,11 +210,18 @@ func (vm VirtualMachine) DeleteVM(ctx context.Context) error {
 }
 
 // AttachDisk attaches the disk at location - vmDiskPath to the Virtual Machine
-// Additionally the disk can be configured with SPBM policy if storagePolicyID is non-empty.
-func (vm VirtualMachine) AttachDisk(ctx context.Context, vmDiskPath string, storagePolicyID string, diskControllerType string) (diskUUID string, err error) {
+// Additionally the disk can be configured with SPBM policy if volOptions.StoragePolicyID
+// is non-empty, and shared across multiple VMs if volOptions.MultiWriter is set.
+// vmDiskPath must already name a concrete Datastore; when volOptions.StoragePod is
+// set it is resolved to a concrete Datastore by diskmanagers.VirtualDisk.Create
+// before the disk is created, so AttachDisk itself never has to consult SDRS.
+func (vm VirtualMachine) AttachDisk(ctx context.Context, vmDiskPath string, volOptions *VolumeOptions) (diskUUID string, err error) {
+	if volOptions == nil {
+		return "", fmt.Errorf("volOptions must not be nil")
+	}
 	var newSCSIController types.BaseVirtualDevice
 	// Check if the diskControllerType is valid
-	if !CheckControllerSupported(diskControllerType) {
+	if !CheckControllerSupported(volOptions.SCSIControllerType) {
 		return "", fmt.Errorf("Not a valid SCSI Controller Type. Valid options are %q", SCSIControllerTypeValidOptions())
 	}
 	attached, err := vm.IsDiskAttached(ctx, vmDiskPath)
@@ -145,11 +234,16 @@ func (vm VirtualMachine) AttachDisk(ctx context.Context, vmDiskPath string, stor
 		return diskUUID, nil
 	}
 
-	disk, newSCSIController, err := createDiskSpec(ctx, vm, vmDiskPath, VolumeOptions{SCSIControllerType: diskControllerType})
+	disk, newSCSIController, err := createDiskSpec(ctx, vm, vmDiskPath, *volOptions)
 	if err != nil {
 		glog.Errorf("Error occurred while creating disk spec, err: %v", err)
 		return "", err
 	}
+	if volOptions.MultiWriter {
+		if backing, ok := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo); ok {
+			backing.Sharing = types.VirtualDiskSharingSharingMultiWriter
+		}
+	}
 	virtualMachineConfigSpec := types.VirtualMachineConfigSpec{}
 	deviceConfigSpec := &types.VirtualDeviceConfigSpec{
 		Device:    disk,
@@ -157,40 +251,40 @@ func (vm VirtualMachine) AttachDisk(ctx context.Context, vmDiskPath string, stor
 	}
 
 	// Configure the disk with the SPBM profile only if ProfileID is not empty.
-	if storagePolicyID != "" {
+	if volOptions.StoragePolicyID != "" {
 		profileSpec := &types.VirtualMachineDefinedProfileSpec{
-			ProfileId: storagePolicyID,
+			ProfileId: volOptions.StoragePolicyID,
 		}
 		deviceConfigSpec.Profile = append(deviceConfigSpec.Profile, profileSpec)
 	}
 	virtualMachineConfigSpec.DeviceChange = append(virtualMachineConfigSpec.DeviceChange, deviceConfigSpec)
 	task, err := vm.Reconfigure(ctx, virtualMachineConfigSpec)
 	if err != nil {
-		glog.Errorf("Failed to attach the disk with storagePolicy: %+q with err - %v", storagePolicyID, err)
+		glog.Errorf("Failed to attach the disk with storagePolicy: %+q with err - %v", volOptions.StoragePolicyID, err)
 		if newSCSIController != nil {
 			vm.DeleteController(ctx, newSCSIController)
 		}
-		return "", "", err
+		return "", err
 	}
 	err = task.Wait(ctx)
 	if err != nil {
-		glog.Errorf("Failed to attach the disk with storagePolicy: %+q with err - %v", storagePolicyID, err)
+		glog.Errorf("Failed to attach the disk with storagePolicy: %+q with err - %v", volOptions.StoragePolicyID, err)
 		if newSCSIController != nil {
 			vm.DeleteController(ctx, newSCSIController)
 		}
-		return "", "", err
+		return "", err
 	}
 
-	deviceName, diskUUID, err := vm.GetVMDiskInfo(ctx, disk)
+	_, diskUUID, err = vm.GetVMDiskInfo(ctx, disk)
 	if err != nil {
 		glog.Errorf("Error occurred while getting Disk Info, err: %v", err)
 		if newSCSIController != nil {
 			vm.DeleteController(ctx, newSCSIController)
 		}
 		vm.DetachDisk(ctx, vmDiskPath)
-		return "", "", err
+		return "", err
 	}
-	return deviceName, diskUUID, nil
+	return diskUUID, nil
 }
 
 func (vm VirtualMachine) GetVMDiskInfo(ctx context.Context, disk *types.VirtualDisk) (string, string, error) {
@@ -245,6 +339,212 @@ func (vm VirtualMachine) DetachDisk(ctx context.Context, vmDiskPath string) erro
 	return nil
 }
 
+// AttachSpec describes a single disk to attach as part of a batched AttachDisks call.
+type AttachSpec struct {
+	VMDiskPath    string
+	VolumeOptions *VolumeOptions
+}
+
+// AttachResult is the outcome of attaching one disk via AttachDisks.
+type AttachResult struct {
+	VMDiskPath string
+	DiskUUID   string
+}
+
+// AttachDisks attaches multiple disks to the VM with a single Reconfigure
+// task, which is both faster and atomic compared to calling AttachDisk once
+// per disk - useful when a pod needs several PVs (e.g. separate data/WAL/log
+// disks for a database) attached to the same node at once. Unlike AttachDisk,
+// it never calls createAndAttachSCSIController: any SCSI controller a spec
+// needs is added as an Operation=Add device change in the same
+// VirtualMachineConfigSpec as the disks, so the whole batch commits - or
+// fails - as the single Reconfigure below, instead of each controller
+// committing itself ahead of the disk it backs. Specs are also packed onto
+// existing controllers with a free slot (including ones just added earlier in
+// this same batch) rather than given one new controller each, so a batch of
+// 4 or more disks doesn't need 4 or more SCSI controllers.
+func (vm VirtualMachine) AttachDisks(ctx context.Context, specs []AttachSpec) ([]AttachResult, error) {
+	vmDevices, err := vm.Device(ctx)
+	if err != nil {
+		glog.Errorf("Failed to retrieve VM devices, err: %v", err)
+		return nil, err
+	}
+	batchDevices := append(object.VirtualDeviceList{}, vmDevices...)
+	numSCSIControllers := len(getSCSIControllers(vmDevices))
+
+	virtualMachineConfigSpec := types.VirtualMachineConfigSpec{}
+	disks := make([]*types.VirtualDisk, len(specs))
+
+	for i, spec := range specs {
+		if !CheckControllerSupported(spec.VolumeOptions.SCSIControllerType) {
+			return nil, fmt.Errorf("Not a valid SCSI Controller Type. Valid options are %q", SCSIControllerTypeValidOptions())
+		}
+		attached, err := vm.IsDiskAttached(ctx, spec.VMDiskPath)
+		if err != nil {
+			glog.Errorf("Error occurred while checking if disk is attached. vmDiskPath: %q, err: %+v", spec.VMDiskPath, err)
+			return nil, err
+		}
+		if attached {
+			continue
+		}
+
+		sharing := getSCSISharingType(spec.VolumeOptions.SCSISharing)
+		controller := pickSCSIController(batchDevices, spec.VolumeOptions.SCSIControllerType, sharing)
+		if controller == nil {
+			if numSCSIControllers >= SCSIControllerLimit {
+				glog.Errorf("SCSI Controller Limit of %d has been reached, cannot create another SCSI controller", SCSIControllerLimit)
+				return nil, fmt.Errorf("SCSI Controller Limit of %d has been reached, cannot create another SCSI controller", SCSIControllerLimit)
+			}
+			newController, err := batchDevices.CreateSCSIController(spec.VolumeOptions.SCSIControllerType)
+			if err != nil {
+				glog.Errorf("Failed to create new SCSI controller: %+v", err)
+				return nil, err
+			}
+			configNewController := newController.(types.BaseVirtualSCSIController).GetVirtualSCSIController()
+			hotAddRemove := true
+			configNewController.HotAddRemove = &hotAddRemove
+			configNewController.SharedBus = sharing
+
+			virtualMachineConfigSpec.DeviceChange = append(virtualMachineConfigSpec.DeviceChange, &types.VirtualDeviceConfigSpec{
+				Device:    newController,
+				Operation: types.VirtualDeviceConfigSpecOperationAdd,
+			})
+			batchDevices = append(batchDevices, newController)
+			numSCSIControllers++
+			controller = newController
+		}
+
+		disk := batchDevices.CreateDisk(controller.(types.BaseVirtualController), types.ManagedObjectReference{}, spec.VMDiskPath)
+		backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+		backing.DiskMode = string(types.VirtualDiskModeIndependent_persistent)
+		if spec.VolumeOptions.CapacityKB != 0 {
+			disk.CapacityInKB = int64(spec.VolumeOptions.CapacityKB)
+		}
+		if spec.VolumeOptions.MultiWriter {
+			backing.Sharing = types.VirtualDiskSharingSharingMultiWriter
+		}
+		batchDevices = append(batchDevices, disk)
+		disks[i] = disk
+
+		deviceConfigSpec := &types.VirtualDeviceConfigSpec{
+			Device:    disk,
+			Operation: types.VirtualDeviceConfigSpecOperationAdd,
+		}
+		if spec.VolumeOptions.StoragePolicyID != "" {
+			deviceConfigSpec.Profile = append(deviceConfigSpec.Profile, &types.VirtualMachineDefinedProfileSpec{
+				ProfileId: spec.VolumeOptions.StoragePolicyID,
+			})
+		}
+		virtualMachineConfigSpec.DeviceChange = append(virtualMachineConfigSpec.DeviceChange, deviceConfigSpec)
+	}
+
+	if len(virtualMachineConfigSpec.DeviceChange) > 0 {
+		task, err := vm.Reconfigure(ctx, virtualMachineConfigSpec)
+		if err != nil {
+			glog.Errorf("Failed to attach disks in batch, err: %v", err)
+			return nil, err
+		}
+		if err := task.Wait(ctx); err != nil {
+			glog.Errorf("Failed to attach disks in batch, err: %v", err)
+			return nil, err
+		}
+	}
+
+	results := make([]AttachResult, 0, len(specs))
+	for i, spec := range specs {
+		if disks[i] == nil {
+			// Was already attached before this call.
+			diskUUID, err := vm.GetVirtualDiskUUIDByPath(ctx, spec.VMDiskPath)
+			if err != nil {
+				return nil, err
+			}
+			results = append(results, AttachResult{VMDiskPath: spec.VMDiskPath, DiskUUID: diskUUID})
+			continue
+		}
+		_, diskUUID, err := vm.GetVMDiskInfo(ctx, disks[i])
+		if err != nil {
+			glog.Errorf("Error occurred while getting Disk Info for %q, err: %v", spec.VMDiskPath, err)
+			return nil, err
+		}
+		results = append(results, AttachResult{VMDiskPath: spec.VMDiskPath, DiskUUID: diskUUID})
+	}
+	return results, nil
+}
+
+// DetachDisks detaches multiple disks, identified by their vmDiskPaths, from
+// the VM with a single Reconfigure task.
+func (vm VirtualMachine) DetachDisks(ctx context.Context, vmDiskPaths []string) error {
+	vmDevices, err := vm.Device(ctx)
+	if err != nil {
+		glog.Errorf("Error occurred while getting VM devices, err: %v", err)
+		return err
+	}
+	virtualMachineConfigSpec := types.VirtualMachineConfigSpec{}
+	for _, vmDiskPath := range vmDiskPaths {
+		diskID, err := vm.GetVirtualDiskID(ctx, vmDiskPath)
+		if err != nil {
+			glog.Errorf("disk ID not found for %v ", vmDiskPath)
+			return err
+		}
+		device := vmDevices.Find(diskID)
+		if device == nil {
+			return fmt.Errorf("device '%s' not found", diskID)
+		}
+		virtualMachineConfigSpec.DeviceChange = append(virtualMachineConfigSpec.DeviceChange, &types.VirtualDeviceConfigSpec{
+			Device:    device,
+			Operation: types.VirtualDeviceConfigSpecOperationRemove,
+		})
+	}
+	task, err := vm.Reconfigure(ctx, virtualMachineConfigSpec)
+	if err != nil {
+		glog.Errorf("Failed to detach disks in batch, err: %v", err)
+		return err
+	}
+	return task.Wait(ctx)
+}
+
+// ExpandDisk grows the virtual disk at diskPath to newSizeKB via an online
+// Reconfigure, so a PVC can be resized without restarting the pod that owns it.
+func (vm VirtualMachine) ExpandDisk(ctx context.Context, diskPath string, newSizeKB int64) error {
+	vmDevices, err := vm.Device(ctx)
+	if err != nil {
+		glog.Errorf("Failed to get the devices for vm: %+v. err: %+v", vm, err)
+		return err
+	}
+	device, err := vm.getVirtualDeviceByPath(ctx, vmDevices, diskPath)
+	if err != nil {
+		glog.Errorf("Failed to get virtualDevice for path: %q. err: %+v", diskPath, err)
+		return err
+	}
+	if device == nil {
+		return ErrNoDevicesFound
+	}
+	disk, ok := device.(*types.VirtualDisk)
+	if !ok {
+		return fmt.Errorf("Device at path %q is not a VirtualDisk", diskPath)
+	}
+	if newSizeKB <= disk.CapacityInKB {
+		return fmt.Errorf("New size %d KB must be larger than the current size %d KB", newSizeKB, disk.CapacityInKB)
+	}
+	disk.CapacityInKB = newSizeKB
+	disk.CapacityInBytes = newSizeKB * 1024
+
+	virtualMachineConfigSpec := types.VirtualMachineConfigSpec{
+		DeviceChange: []types.BaseVirtualDeviceConfigSpec{
+			&types.VirtualDeviceConfigSpec{
+				Device:    disk,
+				Operation: types.VirtualDeviceConfigSpecOperationEdit,
+			},
+		},
+	}
+	task, err := vm.Reconfigure(ctx, virtualMachineConfigSpec)
+	if err != nil {
+		glog.Errorf("Failed to expand disk %q to %d KB, err: %v", diskPath, newSizeKB, err)
+		return err
+	}
+	return task.Wait(ctx)
+}
+
 // Get VM's Resource Pool
 func (vm VirtualMachine) GetResourcePool(ctx context.Context) (*object.ResourcePool, error) {
 	currentVMHost, err := vm.HostSystem(ctx)
@@ -312,8 +612,70 @@ func (vm VirtualMachine) GetAllAccessibleDatastores(ctx context.Context) ([]Data
 	return dsObjList, nil
 }
 
+// createDiskSpec builds a VirtualDisk device to attach to vm at diskPath,
+// backed by a freshly created SCSI controller of volumeOptions.SCSIControllerType
+// whose SharedBus mode is set from volumeOptions.SCSISharing. It returns the
+// new controller alongside the disk so callers can roll the controller back
+// if attaching the disk subsequently fails.
+func createDiskSpec(ctx context.Context, vm VirtualMachine, diskPath string, volumeOptions VolumeOptions) (*types.VirtualDisk, types.BaseVirtualDevice, error) {
+	newSCSIController, err := vm.createAndAttachSCSIController(ctx, volumeOptions.SCSIControllerType, getSCSISharingType(volumeOptions.SCSISharing))
+	if err != nil {
+		glog.Errorf("Failed to create SCSI controller for VM: %q with err: %+v", vm.InventoryPath, err)
+		return nil, nil, err
+	}
+
+	vmDevices, err := vm.Device(ctx)
+	if err != nil {
+		glog.Errorf("Failed to retrieve VM devices, err: %v", err)
+		vm.DeleteController(ctx, newSCSIController)
+		return nil, nil, err
+	}
+	disk := vmDevices.CreateDisk(newSCSIController.(types.BaseVirtualController), types.ManagedObjectReference{}, diskPath)
+	backing := disk.Backing.(*types.VirtualDiskFlatVer2BackingInfo)
+	backing.DiskMode = string(types.VirtualDiskModeIndependent_persistent)
+	if volumeOptions.CapacityKB != 0 {
+		disk.CapacityInKB = int64(volumeOptions.CapacityKB)
+	}
+	return disk, newSCSIController, nil
+}
+
+// scsiControllerDiskSlots is the number of VirtualDisk devices a single SCSI
+// controller can host: 16 unit numbers per controller, minus unit 7 which
+// vSphere reserves for the controller itself.
+const scsiControllerDiskSlots = 15
+
+// pickSCSIController returns a controller from devices - which may include
+// ones added earlier in the same batch but not yet committed to the VM - of
+// diskControllerType and SharedBus mode sharing that still has a free disk
+// slot, or nil if none qualifies and a new controller must be created.
+func pickSCSIController(devices object.VirtualDeviceList, diskControllerType string, sharing types.VirtualSCSISharing) types.BaseVirtualDevice {
+	for _, device := range devices {
+		scsiController, ok := device.(types.BaseVirtualSCSIController)
+		if !ok || devices.Type(device) != diskControllerType {
+			continue
+		}
+		controller := scsiController.GetVirtualSCSIController()
+		if controller.SharedBus != sharing {
+			continue
+		}
+		attachedDisks := 0
+		for _, d := range devices {
+			if devices.TypeName(d) == "VirtualDisk" && d.GetVirtualDevice().ControllerKey == controller.Key {
+				attachedDisks++
+			}
+		}
+		if attachedDisks < scsiControllerDiskSlots {
+			return device
+		}
+	}
+	return nil
+}
+
 // createAndAttachSCSIController creates and attachs the SCSI controller to the VM.
-func (vm VirtualMachine) createAndAttachSCSIController(ctx context.Context, diskControllerType string) (types.BaseVirtualDevice, error) {
+// sharing controls the SharedBus mode of the new controller; pass
+// types.VirtualSCSISharingNoSharing for a controller that is not shared with
+// other VMs.
+func (vm VirtualMachine) createAndAttachSCSIController(ctx context.Context, diskControllerType string, sharing types.VirtualSCSISharing) (types.BaseVirtualDevice, error) {
 	// Get VM device list
 	vmDevices, err := vm.Device(ctx)
 	if err != nil {
@@ -334,7 +696,7 @@ func (vm VirtualMachine) createAndAttachSCSIController(ctx context.Context, disk
 	configNewSCSIController := newSCSIController.(types.BaseVirtualSCSIController).GetVirtualSCSIController()
 	hotAndRemove := true
 	configNewSCSIController.HotAddRemove = &hotAndRemove
-	configNewSCSIController.SharedBus = types.VirtualSCSISharing(types.VirtualSCSISharingNoSharing)
+	configNewSCSIController.SharedBus = sharing
 
 	// add the scsi controller to virtual machine
 	err = vm.AddDevice(context.TODO(), newSCSIController)
@@ -106,6 +106,45 @@ var _ = framework.KubeDescribe("VSAN policy support for dynamic provisioning [Vo
 		By("Invoking Test for diskformat: thin")
 		invokeVSANPolicyTest(client, namespace, scParameters)
 	})
+
+	// Named SPBM policy that is compatible with the default datastore.
+	scParameters = make(map[string]string)
+	scParameters["storagePolicyName"] = "gold"
+	framework.Logf("Invoking Test for SPBM storage policy: %+v", scParameters)
+	It("verify dynamically provisioned pv using storageclass with a named storage policy is created and attached successfully", func() {
+		By("Invoking Test for SPBM storage policy on a compatible datastore")
+		invokeStoragePolicyNameTest(client, namespace, scParameters, true)
+	})
+
+	// Named SPBM policy that is not compatible with the selected datastore.
+	scParameters = make(map[string]string)
+	scParameters["storagePolicyName"] = "gold"
+	scParameters["datastore"] = "vsanDatastoreIncompatible"
+	framework.Logf("Invoking Test for SPBM storage policy: %+v", scParameters)
+	It("verify dynamic provisioning fails with a clear error when the named storage policy is incompatible with the datastore", func() {
+		By("Invoking Test for SPBM storage policy on an incompatible datastore")
+		invokeStoragePolicyNameTest(client, namespace, scParameters, false)
+	})
+
+	// Datastore allow-list that contains a compatible datastore.
+	scParameters = make(map[string]string)
+	scParameters["hostFailuresToTolerate"] = "0"
+	scParameters["datastores"] = "sharedVmfs-0,vsanDatastore"
+	framework.Logf("Invoking Test for datastore allow-list placement: %+v", scParameters)
+	It("verify dynamically provisioned pv only uses a datastore from the configured allow-list", func() {
+		By("Invoking Test for datastore allow-list placement")
+		invokeStoragePolicyNameTest(client, namespace, scParameters, true, "sharedVmfs-0", "vsanDatastore")
+	})
+
+	// Datastore allow-list with no datastore compatible with the VSAN capabilities requested.
+	scParameters = make(map[string]string)
+	scParameters["hostFailuresToTolerate"] = "0"
+	scParameters["datastores"] = "nonExistentDatastore"
+	framework.Logf("Invoking Test for datastore allow-list placement with no compatible datastores: %+v", scParameters)
+	It("verify provisioning fails with a ProvisioningFailed event rather than a timeout when the datastore allow-list has no compatible candidates", func() {
+		By("Invoking Test for empty datastore allow-list placement")
+		invokeStoragePolicyNameTest(client, namespace, scParameters, false)
+	})
 })
 
 func invokeVSANPolicyTest(client clientset.Interface, namespace string, scParameters map[string]string) {
@@ -177,3 +216,108 @@ func invokeVSANPolicyTest(client clientset.Interface, namespace string, scParame
 	deletePodAndWaitForVolumeToDetach(client, namespace, vsp, nodeName, pod, pv.Spec.VsphereVolume.VolumePath)
 
 }
+
+// invokeStoragePolicyNameTest exercises the datastore placement StorageClass
+// parameters: "storagePolicyName", which names a pre-created SPBM policy in
+// vCenter instead of inlining individual VSAN capabilities, and the
+// "datastores" allow-list. When expectSuccess is false, scParameters is
+// expected to leave no datastore the volume can be placed on, and
+// provisioning should fail fast with a ProvisioningFailed event on the PVC
+// rather than time out. When allowedDatastores is non-empty, the bound PV's
+// volume must be on one of them.
+func invokeStoragePolicyNameTest(client clientset.Interface, namespace string, scParameters map[string]string, expectSuccess bool, allowedDatastores ...string) {
+	By("Creating Storage Class With datastore placement params")
+	storageClassSpec := getVSphereStorageClassSpec("storagepolicysc", scParameters)
+	storageclass, err := client.StorageV1beta1().StorageClasses().Create(storageClassSpec)
+	if err != nil {
+		framework.Logf("Failed to create storage class with err: %+v", err)
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	defer client.StorageV1beta1().StorageClasses().Delete(storageclass.Name, nil)
+
+	By("Creating PVC using the Storage Class")
+	pvclaimSpec := getVSphereClaimSpecWithStorageClassAnnotation(namespace, storageclass)
+	pvclaim, err := client.CoreV1().PersistentVolumeClaims(namespace).Create(pvclaimSpec)
+	if err != nil {
+		framework.Logf("Failed to create PVC with err: %+v", err)
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	defer func() {
+		client.CoreV1().PersistentVolumeClaims(namespace).Delete(pvclaimSpec.Name, nil)
+	}()
+
+	if !expectSuccess {
+		By("Expecting a ProvisioningFailed event on the PVC instead of it becoming bound")
+		Eventually(func() bool {
+			events, err := client.CoreV1().Events(namespace).List(metav1.ListOptions{})
+			if err != nil {
+				return false
+			}
+			return eventExistsWithReason(events, pvclaim.Name, "ProvisioningFailed")
+		}, framework.ClaimProvisionTimeout, framework.Poll).Should(BeTrue())
+		return
+	}
+
+	By("Waiting for claim to be in bound phase")
+	err = framework.WaitForPersistentVolumeClaimPhase(v1.ClaimBound, client, pvclaim.Namespace, pvclaim.Name, framework.Poll, framework.ClaimProvisionTimeout)
+	if err != nil {
+		framework.Logf("Failed to bound PVC with err: %+v", err)
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	// Get new copy of the claim
+	pvclaim, err = client.CoreV1().PersistentVolumeClaims(pvclaim.Namespace).Get(pvclaim.Name, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	// Get the bound PV
+	pv, err := client.CoreV1().PersistentVolumes().Get(pvclaim.Spec.VolumeName, metav1.GetOptions{})
+	Expect(err).NotTo(HaveOccurred())
+
+	if len(allowedDatastores) > 0 {
+		By("Verifying the PV was placed on a datastore from the allow-list")
+		volumePath := pv.Spec.VsphereVolume.VolumePath
+		onAllowedDatastore := false
+		for _, datastore := range allowedDatastores {
+			prefix := "[" + datastore + "]"
+			if len(volumePath) >= len(prefix) && volumePath[:len(prefix)] == prefix {
+				onAllowedDatastore = true
+			}
+		}
+		Expect(onAllowedDatastore).To(BeTrue(), "volume %q is not on a datastore from the allow-list %v", volumePath, allowedDatastores)
+	}
+
+	By("Creating pod to attach PV to the node")
+	podSpec := getVSpherePodSpecWithClaim(pvclaim.Name, nil, "while true ; do sleep 2 ; done")
+	pod, err := client.CoreV1().Pods(namespace).Create(podSpec)
+	if err != nil {
+		framework.Logf("Failed to create pod spec with err: %+v", err)
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	By("Waiting for pod to be running")
+	Expect(framework.WaitForPodNameRunningInNamespace(client, pod.Name, namespace)).To(Succeed())
+
+	// get fresh pod info
+	pod, err = client.CoreV1().Pods(namespace).Get(pod.Name, metav1.GetOptions{})
+	nodeName := pod.Spec.NodeName
+
+	vsp, err := vsphere.GetVSphere()
+	Expect(err).NotTo(HaveOccurred())
+	verifyVSphereDiskAttached(vsp, pv.Spec.VsphereVolume.VolumePath, k8stype.NodeName(nodeName))
+
+	By("Delete pod and wait for volume to be detached from node")
+	deletePodAndWaitForVolumeToDetach(client, namespace, vsp, nodeName, pod, pv.Spec.VsphereVolume.VolumePath)
+}
+
+// eventExistsWithReason returns true if any event in the list is about the
+// object named objectName and has the given reason.
+func eventExistsWithReason(events *v1.EventList, objectName string, reason string) bool {
+	for _, event := range events.Items {
+		if event.InvolvedObject.Name == objectName && event.Reason == reason {
+			return true
+		}
+	}
+	return false
+}